@@ -1,30 +1,39 @@
 package jq
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
 
 	"github.com/itchyny/gojq"
+	"github.com/jq-view/jq-view/internal/schema"
 )
 
-// Execute runs a jq expression on input data and returns the result
-// The fieldOrder parameter specifies the desired order of fields in the output
+// Execute runs a jq expression on input data and returns the result.
+//
+// Key order is preserved end to end: the input is decoded into an
+// OrderedMap tree (see orderedmap.go) so we know the original field order
+// at every nesting level, and the query's own object-construction literals
+// (e.g. "{a, b}") are scanned so we know the order the user wrote. gojq's
+// evaluator itself only understands plain map[string]any/[]any/scalars, so
+// evaluation runs against a flattened copy of the input; the recorded
+// orders are reapplied to the result afterwards in marshalOrdered.
 func Execute(expression string, input []byte) ([]byte, error) {
 	query, err := gojq.Parse(expression)
 	if err != nil {
 		return nil, fmt.Errorf("parse error: %w", err)
 	}
 
-	var inputData any
-	if err := json.Unmarshal(input, &inputData); err != nil {
+	ordered, err := decodeOrdered(input)
+	if err != nil {
 		return nil, fmt.Errorf("json error: %w", err)
 	}
+	resolver := newOrderResolver(ordered)
+	exprOrder := scanObjectFields(expression)
 
 	var results []any
-	iter := query.Run(inputData)
+	iter := query.Run(toPlainValue(ordered))
 	for {
 		v, ok := iter.Next()
 		if !ok {
@@ -36,142 +45,268 @@ func Execute(expression string, input []byte) ([]byte, error) {
 		results = append(results, v)
 	}
 
-	// Extract field order from expression
-	fieldOrder := extractFieldOrder(expression)
-
 	if len(results) == 1 {
-		return marshalOrdered(results[0], fieldOrder)
+		return marshalOrdered(results[0], exprOrder, resolver)
 	}
-	return marshalOrdered(results, fieldOrder)
+	return marshalOrdered(results, exprOrder, resolver)
 }
 
-// extractFieldOrder extracts field names from jq expression in order
-func extractFieldOrder(expr string) []string {
-	var fields []string
-
-	// Match patterns like {field1, field2} or {field1: .path, field2: .path}
-	// Find content between { and }
-	start := strings.LastIndex(expr, "{")
-	end := strings.LastIndex(expr, "}")
-	if start == -1 || end == -1 || start >= end {
-		return nil
-	}
+// orderResolver supplies the recorded input key order for each object a
+// query result passes through unchanged (the common case for ".",
+// "select(...)", "map(...)" and similar path queries). Objects are keyed
+// by a signature of their exact key set, since gojq's evaluator hands
+// results back as plain map[string]any with no link to the *OrderedMap
+// they came from.
+//
+// Distinct objects can share a signature (two records with the same
+// fields, written in different orders), so each signature is backed by a
+// FIFO queue of every order recorded for it rather than a single cached
+// value: toOrdered consumes one entry per object it encounters, in the
+// same relative sequence newOrderResolver recorded them in, so the Nth
+// object with a given key set gets the Nth recorded order for it instead
+// of every such object collapsing onto the first one seen.
+type orderResolver struct {
+	queues map[string][][]string
+}
 
-	content := expr[start+1 : end]
-	parts := strings.Split(content, ",")
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		// Handle "field: .path" or just "field"
-		if colonIdx := strings.Index(p, ":"); colonIdx != -1 {
-			field := strings.TrimSpace(p[:colonIdx])
-			fields = append(fields, field)
-		} else {
-			fields = append(fields, p)
+// newOrderResolver walks a decodeOrdered tree and records, in document
+// order, the key order of every object it finds.
+func newOrderResolver(v any) *orderResolver {
+	r := &orderResolver{queues: make(map[string][][]string)}
+	var walk func(any)
+	walk = func(v any) {
+		switch t := v.(type) {
+		case *OrderedMap:
+			sig := keySignature(t.Keys())
+			r.queues[sig] = append(r.queues[sig], t.Keys())
+			for _, k := range t.Keys() {
+				val, _ := t.Get(k)
+				walk(val)
+			}
+		case []any:
+			for _, e := range t {
+				walk(e)
+			}
 		}
 	}
-
-	return fields
+	walk(v)
+	return r
 }
 
-// marshalOrdered marshals JSON with fields in specified order
-func marshalOrdered(data any, fieldOrder []string) ([]byte, error) {
-	if len(fieldOrder) == 0 {
-		return json.MarshalIndent(data, "", "  ")
+// next pops and returns the next recorded order for sig, or nil once its
+// queue is exhausted (no matching input object left to attribute this
+// result object to).
+func (r *orderResolver) next(sig string) []string {
+	q := r.queues[sig]
+	if len(q) == 0 {
+		return nil
 	}
+	r.queues[sig] = q[1:]
+	return q[0]
+}
+
+func keySignature(keys []string) string {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "\x00")
+}
 
-	var buf bytes.Buffer
-	if err := writeOrderedJSON(&buf, data, fieldOrder, 0); err != nil {
-		return nil, err
+// scanObjectFields finds the last top-level object-construction literal in
+// a jq expression (e.g. the "{a, b}" in ".foo | {a, b}") and returns its
+// field names in the order they were written. Unlike a simple
+// strings.LastIndex(expr, "{")/LastIndex(expr, "}") scan, it tracks brace
+// depth and string literals so it finds the literal's own matching closing
+// brace rather than one belonging to a nested object, a pipe chain, or a
+// brace that happens to appear inside a quoted string.
+func scanObjectFields(expr string) []string {
+	start, end := lastTopLevelBraces(expr)
+	if start == -1 {
+		return nil
 	}
-	return buf.Bytes(), nil
+	return splitObjectFields(expr[start+1 : end])
 }
 
-func writeOrderedJSON(buf *bytes.Buffer, data any, fieldOrder []string, indent int) error {
-	indentStr := strings.Repeat("  ", indent)
-	nextIndent := strings.Repeat("  ", indent+1)
+// lastTopLevelBraces returns the byte offsets of the outermost '{' and its
+// matching '}' for the last top-level object literal in expr, or (-1, -1)
+// if none is found.
+func lastTopLevelBraces(expr string) (int, int) {
+	type span struct{ start, end int }
+	var spans []span
 
-	switch v := data.(type) {
-	case map[string]any:
-		buf.WriteString("{\n")
+	depth := 0
+	start := -1
+	inString := false
+	escaped := false
 
-		// Order keys: first by fieldOrder, then alphabetically for remaining
-		orderedKeys := orderKeys(v, fieldOrder)
+	for i, c := range expr {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
 
-		for i, k := range orderedKeys {
-			if i > 0 {
-				buf.WriteString(",\n")
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			if depth == 0 {
+				start = i
 			}
-			buf.WriteString(nextIndent)
-			buf.WriteString(fmt.Sprintf("%q: ", k))
-			if err := writeOrderedJSON(buf, v[k], fieldOrder, indent+1); err != nil {
-				return err
+			depth++
+		case '}':
+			depth--
+			if depth == 0 && start != -1 {
+				spans = append(spans, span{start, i})
+				start = -1
 			}
 		}
-		buf.WriteString("\n")
-		buf.WriteString(indentStr)
-		buf.WriteString("}")
+	}
 
-	case []any:
-		buf.WriteString("[\n")
-		for i, item := range v {
-			if i > 0 {
-				buf.WriteString(",\n")
-			}
-			buf.WriteString(nextIndent)
-			if err := writeOrderedJSON(buf, item, fieldOrder, indent+1); err != nil {
-				return err
+	if len(spans) == 0 {
+		return -1, -1
+	}
+	last := spans[len(spans)-1]
+	return last.start, last.end
+}
+
+// splitObjectFields splits the inside of an object-construction literal on
+// top-level commas (ignoring commas nested in strings, braces, or
+// brackets) and extracts each entry's field name.
+func splitObjectFields(content string) []string {
+	var fields []string
+	var cur strings.Builder
+	depth := 0
+	inString := false
+	escaped := false
+
+	flush := func() {
+		part := strings.TrimSpace(cur.String())
+		cur.Reset()
+		if part == "" {
+			return
+		}
+		if colon := strings.IndexAny(part, ":"); colon != -1 {
+			part = strings.TrimSpace(part[:colon])
+		}
+		part = strings.TrimPrefix(part, "\"")
+		part = strings.TrimSuffix(part, "\"")
+		if part != "" {
+			fields = append(fields, part)
+		}
+	}
+
+	for _, c := range content {
+		if inString {
+			cur.WriteRune(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
 			}
+			continue
 		}
-		buf.WriteString("\n")
-		buf.WriteString(indentStr)
-		buf.WriteString("]")
 
-	default:
-		b, err := json.Marshal(v)
-		if err != nil {
-			return err
+		switch c {
+		case '"':
+			inString = true
+			cur.WriteRune(c)
+		case '{', '[', '(':
+			depth++
+			cur.WriteRune(c)
+		case '}', ']', ')':
+			depth--
+			cur.WriteRune(c)
+		case ',':
+			if depth == 0 {
+				flush()
+				continue
+			}
+			cur.WriteRune(c)
+		default:
+			cur.WriteRune(c)
 		}
-		buf.Write(b)
 	}
+	flush()
 
-	return nil
+	return fields
 }
 
-func orderKeys(m map[string]any, fieldOrder []string) []string {
-	// Create a map of field positions
-	orderMap := make(map[string]int)
-	for i, f := range fieldOrder {
-		orderMap[f] = i
-	}
+// marshalOrdered marshals data as indented JSON with object keys ordered
+// by, in priority: exprOrder (fields written in the query's own object
+// literal), then the order resolver hands back for this object's key set,
+// then alphabetically for anything left over.
+func marshalOrdered(data any, exprOrder []string, resolver *orderResolver) ([]byte, error) {
+	return json.MarshalIndent(toOrdered(data, exprOrder, resolver), "", "  ")
+}
 
-	// Collect all keys
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
+func toOrdered(data any, exprOrder []string, resolver *orderResolver) any {
+	switch v := data.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sig := keySignature(keys)
 
-	// Sort by order: fields in fieldOrder first (by position), then alphabetically
-	sort.Slice(keys, func(i, j int) bool {
-		posI, hasI := orderMap[keys[i]]
-		posJ, hasJ := orderMap[keys[j]]
+		// exprOrder applies to every map matching the shape the query's
+		// own object literal builds, not just the outermost result: a
+		// query like "map({b: .y, a: .x})" or ".[] | {b: .y, a: .x}"
+		// wraps its constructed objects in an array, so exprOrder is
+		// threaded into the []any case below rather than dropped there.
+		// It's only applied where the key set actually matches, so it
+		// doesn't get stamped onto unrelated objects that happen to
+		// share the same array with a constructed one.
+		var order []string
+		if len(exprOrder) > 0 && keySignature(exprOrder) == sig {
+			order = exprOrder
+		} else {
+			order = resolver.next(sig)
+		}
 
-		if hasI && hasJ {
-			return posI < posJ
+		m := NewOrderedMap()
+		seen := make(map[string]bool, len(keys))
+		for _, k := range order {
+			if val, ok := v[k]; ok && !seen[k] {
+				m.Set(k, toOrdered(val, nil, resolver))
+				seen[k] = true
+			}
 		}
-		if hasI {
-			return true
+		sort.Strings(keys)
+		for _, k := range keys {
+			if !seen[k] {
+				m.Set(k, toOrdered(v[k], nil, resolver))
+			}
 		}
-		if hasJ {
-			return false
+		return m
+
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = toOrdered(e, exprOrder, resolver)
 		}
-		return keys[i] < keys[j]
-	})
+		return out
 
-	return keys
+	default:
+		return v
+	}
 }
 
-// BuildExpressionFromFields generates a jq expression from field names
-func BuildExpressionFromFields(fields []string, isArray bool) string {
+// BuildExpressionFromFields generates a jq expression that selects fields
+// off the root document. sch, the document's inferred schema, determines
+// whether the root is an array needing a ".[] |" prefix; pass nil if no
+// schema is available, which is treated as "not an array".
+func BuildExpressionFromFields(fields []string, sch *schema.Schema) string {
+	isArray := sch != nil && sch.IsArray("")
+
 	if len(fields) == 0 {
 		return "."
 	}
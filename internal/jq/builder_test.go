@@ -0,0 +1,88 @@
+package jq
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestExecutePreservesPerObjectKeyOrder guards against the order resolver
+// collapsing distinct objects that share a key set onto a single cached
+// order: each element here has the same keys ("a", "b") but written in a
+// different order, and a pass-through query ('.') must keep each one's
+// own order rather than stamping the first element's order onto the rest.
+func TestExecutePreservesPerObjectKeyOrder(t *testing.T) {
+	input := []byte(`[{"b":1,"a":2},{"a":3,"b":4}]`)
+
+	out, err := Execute(".", input)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	decoded, err := decodeOrdered(out)
+	if err != nil {
+		t.Fatalf("decoding result: %v", err)
+	}
+	arr, ok := decoded.([]any)
+	if !ok || len(arr) != 2 {
+		t.Fatalf("expected a 2-element array, got %#v", decoded)
+	}
+
+	want := [][]string{{"b", "a"}, {"a", "b"}}
+	for i, w := range want {
+		obj, ok := arr[i].(*OrderedMap)
+		if !ok {
+			t.Fatalf("element %d: expected an object, got %#v", i, arr[i])
+		}
+		if got := obj.Keys(); !reflect.DeepEqual(got, w) {
+			t.Errorf("element %d: key order = %v, want %v", i, got, w)
+		}
+	}
+}
+
+// TestExecutePreservesExprOrderInsideArray guards against exprOrder being
+// dropped the moment a constructed object is wrapped in an array result,
+// which is how most real queries that build objects are actually written
+// (".[] | {...}", "map({...})") rather than as a single bare object
+// literal.
+func TestExecutePreservesExprOrderInsideArray(t *testing.T) {
+	input := []byte(`[{"x":1,"y":2},{"x":3,"y":4}]`)
+
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"dot-pipe-object", ".[] | {b: .y, a: .x}"},
+		{"map-object", "map({b: .y, a: .x})"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Execute(tt.expression, input)
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+
+			decoded, err := decodeOrdered(out)
+			if err != nil {
+				t.Fatalf("decoding result: %v", err)
+			}
+			arr, ok := decoded.([]any)
+			if !ok || len(arr) != 2 {
+				t.Fatalf("expected a 2-element array, got %#v", decoded)
+			}
+
+			for i, want := range []int{1, 3} {
+				obj, ok := arr[i].(*OrderedMap)
+				if !ok {
+					t.Fatalf("element %d: expected an object, got %#v", i, arr[i])
+				}
+				if got := obj.Keys(); !reflect.DeepEqual(got, []string{"b", "a"}) {
+					t.Errorf("element %d: key order = %v, want [b a]", i, got)
+				}
+				if v, _ := obj.Get("a"); v != float64(want) {
+					t.Errorf("element %d: a = %v, want %d", i, v, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package jq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// OrderedMap is a JSON object that remembers the order its keys were
+// inserted (or decoded) in, instead of the arbitrary order of a Go map.
+// It implements json.Marshaler so marshaling one always reproduces that
+// order, and decodeOrdered builds one from raw JSON recursively so nested
+// objects keep their own field order too.
+type OrderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+// NewOrderedMap returns an empty OrderedMap.
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{values: make(map[string]any)}
+}
+
+// Set inserts or updates key. The first insertion fixes its position.
+func (m *OrderedMap) Set(key string, value any) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Get returns the value for key and whether it was present.
+func (m *OrderedMap) Get(key string) (any, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Keys returns the map's keys in insertion order.
+func (m *OrderedMap) Keys() []string {
+	return m.keys
+}
+
+// MarshalJSON writes the map's keys and values in insertion order.
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered parses input and returns it as a tree of *OrderedMap,
+// []any, and plain scalars, preserving the key order of every object at
+// every nesting level. This is the ordered counterpart of
+// json.Unmarshal(input, &any{}).
+func decodeOrdered(input []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+	v, err := decodeOrderedValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func decodeOrderedValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return decodeOrderedToken(dec, tok)
+}
+
+func decodeOrderedToken(dec *json.Decoder, tok json.Token) (any, error) {
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			m := NewOrderedMap()
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyTok.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected object key, got %v", keyTok)
+				}
+				val, err := decodeOrderedValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				m.Set(key, val)
+			}
+			if _, err := dec.Token(); err != nil { // consume closing '}'
+				return nil, err
+			}
+			return m, nil
+
+		case '[':
+			var arr []any
+			for dec.More() {
+				val, err := decodeOrderedValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, val)
+			}
+			if _, err := dec.Token(); err != nil { // consume closing ']'
+				return nil, err
+			}
+			return arr, nil
+		}
+	case json.Number:
+		f, err := t.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	return tok, nil
+}
+
+// toPlainValue converts a decodeOrdered tree back into the plain
+// map[string]any / []any / scalar shape gojq's evaluator expects.
+// gojq type-switches on those concrete types, so an *OrderedMap can't be
+// fed to it directly; order is instead reapplied to the query's output by
+// marshalOrdered, using the field order recorded from the expression and
+// from this same input tree.
+func toPlainValue(v any) any {
+	switch t := v.(type) {
+	case *OrderedMap:
+		m := make(map[string]any, len(t.keys))
+		for _, k := range t.keys {
+			val, _ := t.Get(k)
+			m[k] = toPlainValue(val)
+		}
+		return m
+	case []any:
+		out := make([]any, len(t))
+		for i, e := range t {
+			out[i] = toPlainValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,64 @@
+package jq
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/itchyny/gojq"
+)
+
+// StreamOpts configures ExecuteStream.
+type StreamOpts struct {
+	// Compact, when true, writes each result as a single line (NDJSON) to
+	// w instead of indented JSON. The zero value writes indented JSON;
+	// /api/query/stream sets Compact.
+	Compact bool
+}
+
+// ExecuteStream runs expression against r treated as newline-delimited JSON
+// (one JSON value per line), writing each result to w as soon as it is
+// produced. Unlike Execute, the input is never fully materialized in
+// memory, so it is suitable for multi-GB NDJSON/log files.
+//
+// The query is compiled once and reused for every line.
+func ExecuteStream(expression string, r io.Reader, w io.Writer, opts StreamOpts) error {
+	query, err := gojq.Parse(expression)
+	if err != nil {
+		return fmt.Errorf("parse error: %w", err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return fmt.Errorf("compile error: %w", err)
+	}
+
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+	if !opts.Compact {
+		enc.SetIndent("", "  ")
+	}
+
+	for {
+		var line any
+		if err := dec.Decode(&line); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("json error: %w", err)
+		}
+
+		iter := code.Run(line)
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				break
+			}
+			if err, ok := v.(error); ok {
+				return err
+			}
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package jq
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExecuteStreamCompact(t *testing.T) {
+	input := strings.NewReader("{\"x\":1}\n{\"x\":2}\n{\"x\":3}\n")
+	var out strings.Builder
+
+	if err := ExecuteStream(".x", input, &out, StreamOpts{Compact: true}); err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+
+	want := "1\n2\n3\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestExecuteStreamIndented(t *testing.T) {
+	input := strings.NewReader(`{"a":1,"b":2}` + "\n")
+	var out strings.Builder
+
+	if err := ExecuteStream(".", input, &out, StreamOpts{}); err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "\n  ") {
+		t.Errorf("output = %q, want indented JSON", out.String())
+	}
+}
+
+func TestExecuteStreamMultipleResultsPerLine(t *testing.T) {
+	input := strings.NewReader("[1,2,3]\n")
+	var out strings.Builder
+
+	if err := ExecuteStream(".[]", input, &out, StreamOpts{Compact: true}); err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+
+	want := "1\n2\n3\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestExecuteStreamParseError(t *testing.T) {
+	input := strings.NewReader("{\"x\":1}\n")
+	var out strings.Builder
+
+	if err := ExecuteStream("this is not jq :::", input, &out, StreamOpts{}); err == nil {
+		t.Fatal("ExecuteStream: expected a parse error, got nil")
+	}
+}
+
+func TestExecuteStreamInvalidJSONLine(t *testing.T) {
+	input := strings.NewReader("not json\n")
+	var out strings.Builder
+
+	if err := ExecuteStream(".", input, &out, StreamOpts{}); err == nil {
+		t.Fatal("ExecuteStream: expected a decode error, got nil")
+	}
+}
@@ -0,0 +1,197 @@
+// Package schema infers a JSON Schema from a loaded document and answers
+// path-based questions about it (is this field an array, what fields exist
+// under it), backing the editor's autocomplete and the isArray heuristics
+// that used to be supplied by hand.
+package schema
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// maxEnumCandidates caps how many distinct string values a field can have
+// before we stop treating it as an enum and consider it free-form text.
+const maxEnumCandidates = 10
+
+// Schema is a (small) inferred JSON Schema: enough to drive autocomplete
+// and array-vs-object decisions, not a full implementation of the spec.
+type Schema struct {
+	Type       string             `json:"type"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Enum       []string           `json:"enum,omitempty"`
+	Minimum    *float64           `json:"minimum,omitempty"`
+	Maximum    *float64           `json:"maximum,omitempty"`
+}
+
+// Infer walks data and produces its Schema.
+func Infer(data []byte) (*Schema, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return inferValue(v), nil
+}
+
+func inferValue(v any) *Schema {
+	switch t := v.(type) {
+	case map[string]any:
+		props := make(map[string]*Schema, len(t))
+		required := make([]string, 0, len(t))
+		for k, val := range t {
+			props[k] = inferValue(val)
+			required = append(required, k)
+		}
+		sort.Strings(required)
+		return &Schema{Type: "object", Properties: props, Required: required}
+
+	case []any:
+		s := &Schema{Type: "array"}
+		if len(t) > 0 {
+			s.Items = inferArrayItems(t)
+		}
+		return s
+
+	case string:
+		return &Schema{Type: "string"}
+	case float64:
+		return &Schema{Type: "number"}
+	case bool:
+		return &Schema{Type: "boolean"}
+	case nil:
+		return &Schema{Type: "null"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// inferArrayItems merges the schemas of every element of a JSON array. For
+// arrays of objects, a field is Required only if it appears in every
+// element, string fields with few distinct values get an Enum, and numeric
+// fields get a Minimum/Maximum across all elements.
+func inferArrayItems(items []any) *Schema {
+	for _, it := range items {
+		if _, ok := it.(map[string]any); !ok {
+			return inferValue(items[0])
+		}
+	}
+
+	presence := make(map[string]int)
+	props := make(map[string]*Schema)
+	stringValues := make(map[string]map[string]bool)
+	numMin := make(map[string]float64)
+	numMax := make(map[string]float64)
+	numSeen := make(map[string]bool)
+
+	for _, it := range items {
+		obj := it.(map[string]any)
+		for k, val := range obj {
+			presence[k]++
+			if _, ok := props[k]; !ok {
+				props[k] = inferValue(val)
+			}
+			switch vv := val.(type) {
+			case string:
+				if stringValues[k] == nil {
+					stringValues[k] = make(map[string]bool)
+				}
+				stringValues[k][vv] = true
+			case float64:
+				if !numSeen[k] || vv < numMin[k] {
+					numMin[k] = vv
+				}
+				if !numSeen[k] || vv > numMax[k] {
+					numMax[k] = vv
+				}
+				numSeen[k] = true
+			}
+		}
+	}
+
+	var required []string
+	for k, sch := range props {
+		if presence[k] == len(items) {
+			required = append(required, k)
+		}
+		if sch.Type == "string" {
+			if vals := stringValues[k]; len(vals) > 0 && len(vals) <= maxEnumCandidates {
+				enum := make([]string, 0, len(vals))
+				for v := range vals {
+					enum = append(enum, v)
+				}
+				sort.Strings(enum)
+				sch.Enum = enum
+			}
+		}
+		if sch.Type == "number" && numSeen[k] {
+			min, max := numMin[k], numMax[k]
+			sch.Minimum = &min
+			sch.Maximum = &max
+		}
+	}
+	sort.Strings(required)
+
+	return &Schema{Type: "object", Properties: props, Required: required}
+}
+
+// LookupPath resolves a jq-style path (e.g. ".foo.bar") against the schema,
+// descending through array Items transparently, and returns the Schema at
+// that location or nil if it doesn't resolve.
+func (s *Schema) LookupPath(path string) *Schema {
+	cur := s
+	for _, seg := range splitPath(path) {
+		if cur == nil {
+			return nil
+		}
+		if cur.Type == "array" {
+			cur = cur.Items
+		}
+		if cur == nil || cur.Properties == nil {
+			return nil
+		}
+		cur = cur.Properties[seg]
+	}
+	return cur
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, ".")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// IsArray reports whether the schema at path (root, if path is empty)
+// describes a JSON array.
+func (s *Schema) IsArray(path string) bool {
+	target := s
+	if path != "" {
+		target = s.LookupPath(path)
+	}
+	return target != nil && target.Type == "array"
+}
+
+// CompletionsFor returns the field names available at a jq expression
+// prefix, e.g. CompletionsFor(".foo.") lists the keys under .foo (or under
+// each element of .foo, if .foo is an array).
+func (s *Schema) CompletionsFor(prefix string) []string {
+	target := s.LookupPath(prefix)
+	if target == nil {
+		return nil
+	}
+	if target.Type == "array" {
+		target = target.Items
+	}
+	if target == nil || target.Properties == nil {
+		return nil
+	}
+	names := make([]string, 0, len(target.Properties))
+	for k := range target.Properties {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
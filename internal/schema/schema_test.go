@@ -0,0 +1,135 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInferObject(t *testing.T) {
+	sch, err := Infer([]byte(`{"name":"Alice","age":30}`))
+	if err != nil {
+		t.Fatalf("Infer: %v", err)
+	}
+	if sch.Type != "object" {
+		t.Fatalf("Type = %q, want %q", sch.Type, "object")
+	}
+	if sch.Properties["name"].Type != "string" {
+		t.Errorf("Properties[name].Type = %q, want %q", sch.Properties["name"].Type, "string")
+	}
+	if sch.Properties["age"].Type != "number" {
+		t.Errorf("Properties[age].Type = %q, want %q", sch.Properties["age"].Type, "number")
+	}
+	want := []string{"age", "name"}
+	if !reflect.DeepEqual(sch.Required, want) {
+		t.Errorf("Required = %v, want %v", sch.Required, want)
+	}
+}
+
+func TestInferArrayOfObjectsRequiredFields(t *testing.T) {
+	data := []byte(`[{"id":1,"name":"a"},{"id":2}]`)
+	sch, err := Infer(data)
+	if err != nil {
+		t.Fatalf("Infer: %v", err)
+	}
+	if sch.Type != "array" {
+		t.Fatalf("Type = %q, want %q", sch.Type, "array")
+	}
+
+	items := sch.Items
+	if items == nil {
+		t.Fatal("Items is nil")
+	}
+	// "id" appears in every element, "name" does not.
+	want := []string{"id"}
+	if !reflect.DeepEqual(items.Required, want) {
+		t.Errorf("Items.Required = %v, want %v", items.Required, want)
+	}
+}
+
+func TestInferEnumForLowCardinalityStrings(t *testing.T) {
+	data := []byte(`[{"status":"ok"},{"status":"ok"},{"status":"fail"}]`)
+	sch, err := Infer(data)
+	if err != nil {
+		t.Fatalf("Infer: %v", err)
+	}
+	status := sch.Items.Properties["status"]
+	want := []string{"fail", "ok"}
+	if !reflect.DeepEqual(status.Enum, want) {
+		t.Errorf("status.Enum = %v, want %v", status.Enum, want)
+	}
+}
+
+func TestInferMinMaxForNumericFields(t *testing.T) {
+	data := []byte(`[{"n":1},{"n":5},{"n":-2}]`)
+	sch, err := Infer(data)
+	if err != nil {
+		t.Fatalf("Infer: %v", err)
+	}
+	n := sch.Items.Properties["n"]
+	if n.Minimum == nil || *n.Minimum != -2 {
+		t.Errorf("Minimum = %v, want -2", n.Minimum)
+	}
+	if n.Maximum == nil || *n.Maximum != 5 {
+		t.Errorf("Maximum = %v, want 5", n.Maximum)
+	}
+}
+
+func TestIsArrayAndCompletionsFor(t *testing.T) {
+	data := []byte(`{"items":[{"id":1,"tag":"x"}]}`)
+	sch, err := Infer(data)
+	if err != nil {
+		t.Fatalf("Infer: %v", err)
+	}
+
+	if sch.IsArray("") {
+		t.Error("IsArray(\"\") = true, want false for a root object")
+	}
+	if !sch.IsArray("items") {
+		t.Error("IsArray(\"items\") = false, want true")
+	}
+
+	got := sch.CompletionsFor("items")
+	want := []string{"id", "tag"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CompletionsFor(\"items\") = %v, want %v", got, want)
+	}
+
+	if got := sch.CompletionsFor("missing"); got != nil {
+		t.Errorf("CompletionsFor(\"missing\") = %v, want nil", got)
+	}
+}
+
+func TestValidateValidData(t *testing.T) {
+	data := []byte(`{"name":"Alice"}`)
+	schemaDoc := []byte(`{"type":"object","required":["name"],"properties":{"name":{"type":"string"}}}`)
+
+	errs, err := Validate(data, schemaDoc)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(errs) != 0 {
+		t.Errorf("Validate() = %v, want no errors", errs)
+	}
+}
+
+func TestValidateReportsFailures(t *testing.T) {
+	data := []byte(`{"age":"not a number"}`)
+	schemaDoc := []byte(`{"type":"object","required":["name"],"properties":{"age":{"type":"number"}}}`)
+
+	errs, err := Validate(data, schemaDoc)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(errs) == 0 {
+		t.Fatal("Validate() = no errors, want at least one (missing required field and wrong type)")
+	}
+}
+
+func TestValidateInvalidSchema(t *testing.T) {
+	data := []byte(`{}`)
+	schemaDoc := []byte(`not a schema`)
+
+	if _, err := Validate(data, schemaDoc); err == nil {
+		t.Fatal("Validate: expected an error for a malformed schema document")
+	}
+}
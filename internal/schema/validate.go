@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ValidationError is one failing JSON Schema constraint, located by its
+// JSON pointer into the validated document.
+type ValidationError struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+// Validate checks data against the JSON Schema document schemaDoc and
+// returns one ValidationError per failing leaf constraint. A nil, empty
+// slice means data is valid.
+func Validate(data, schemaDoc []byte) ([]ValidationError, error) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("schema.json", bytes.NewReader(schemaDoc)); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	sch, err := compiler.Compile("schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("invalid data: %w", err)
+	}
+
+	if err := sch.Validate(v); err != nil {
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			return []ValidationError{{Message: err.Error()}}, nil
+		}
+		return flattenValidationError(ve), nil
+	}
+	return nil, nil
+}
+
+// flattenValidationError walks the ValidationError cause tree jsonschema
+// builds (one node per schema keyword it tried) down to its leaves, which
+// are the individual constraint failures worth surfacing to the user.
+func flattenValidationError(ve *jsonschema.ValidationError) []ValidationError {
+	if len(ve.Causes) == 0 {
+		return []ValidationError{{Pointer: ve.InstanceLocation, Message: ve.Message}}
+	}
+
+	var out []ValidationError
+	for _, cause := range ve.Causes {
+		out = append(out, flattenValidationError(cause)...)
+	}
+	return out
+}
@@ -0,0 +1,251 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBrowseDepth bounds how far /api/browse will recurse into subdirectories.
+const maxBrowseDepth = 8
+
+// FileInfo describes one entry (file or directory) returned by /api/browse.
+type FileInfo struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"` // relative to the browse root
+	IsDir   bool      `json:"isDir"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+
+	// ItemCount is the number of elements for a top-level JSON array, and
+	// KeyCount is the number of keys for a top-level JSON object. Only one
+	// of the two is populated, and both are omitted for directories and
+	// non-JSON files.
+	ItemCount int `json:"itemCount,omitempty"`
+	KeyCount  int `json:"keyCount,omitempty"`
+}
+
+// BrowseResponse is the payload returned by /api/browse.
+type BrowseResponse struct {
+	Items []FileInfo `json:"items"`
+	Path  string     `json:"path"`
+	Error string     `json:"error,omitempty"`
+}
+
+// resolveBrowsePath resolves a user-supplied relative path against root,
+// rejecting any path that would escape it (e.g. via "..").
+func resolveBrowsePath(root, relPath string) (string, error) {
+	relPath = strings.TrimPrefix(relPath, "/")
+	cleaned := filepath.Clean(filepath.Join(root, relPath))
+
+	rootAbs, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	cleanedAbs, err := filepath.Abs(cleaned)
+	if err != nil {
+		return "", err
+	}
+
+	if cleanedAbs != rootAbs && !strings.HasPrefix(cleanedAbs, rootAbs+string(filepath.Separator)) {
+		return "", fmt.Errorf("path escapes root")
+	}
+	return cleanedAbs, nil
+}
+
+// browseDir lists the JSON files and subdirectories under dir (relative to
+// root), recursing up to maxDepth levels, and sorts the result by sortBy
+// ("name", "size", or "mtime").
+func browseDir(root, dir string, maxDepth int, sortBy string) ([]FileInfo, error) {
+	items, err := browseDirRecursive(root, dir, 0, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	sortFileInfos(items, sortBy)
+	return items, nil
+}
+
+func browseDirRecursive(root, dir string, depth, maxDepth int) ([]FileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []FileInfo
+	for _, e := range entries {
+		full := filepath.Join(dir, e.Name())
+		rel, err := filepath.Rel(root, full)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		if e.IsDir() {
+			items = append(items, FileInfo{
+				Name:    e.Name(),
+				Path:    filepath.ToSlash(rel),
+				IsDir:   true,
+				ModTime: info.ModTime(),
+			})
+			if depth < maxDepth {
+				children, err := browseDirRecursive(root, full, depth+1, maxDepth)
+				if err == nil {
+					items = append(items, children...)
+				}
+			}
+			continue
+		}
+
+		if filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+
+		fi := FileInfo{
+			Name:    e.Name(),
+			Path:    filepath.ToSlash(rel),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}
+		if count, isArray, err := jsonHeaderCount(full); err == nil {
+			if isArray {
+				fi.ItemCount = count
+			} else {
+				fi.KeyCount = count
+			}
+		}
+		items = append(items, fi)
+	}
+
+	return items, nil
+}
+
+func sortFileInfos(items []FileInfo, sortBy string) {
+	less := func(i, j int) bool { return strings.ToLower(items[i].Name) < strings.ToLower(items[j].Name) }
+	switch sortBy {
+	case "size":
+		less = func(i, j int) bool { return items[i].Size < items[j].Size }
+	case "mtime":
+		less = func(i, j int) bool { return items[i].ModTime.After(items[j].ModTime) }
+	}
+
+	// Directories always sort before files, ties broken by the chosen key.
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].IsDir != items[j].IsDir {
+			return items[i].IsDir
+		}
+		return less(i, j)
+	})
+}
+
+// jsonHeaderCount streams just enough of the file at path to determine
+// whether its top-level value is an array or an object, and counts its
+// elements (array length or object key count) without unmarshaling the
+// whole document.
+func jsonHeaderCount(path string) (count int, isArray bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, false, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return 0, false, fmt.Errorf("not an array or object")
+	}
+
+	switch delim {
+	case '[':
+		isArray = true
+		for dec.More() {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return count, isArray, err
+			}
+			count++
+		}
+	case '{':
+		for dec.More() {
+			if _, err := dec.Token(); err != nil { // key
+				return count, isArray, err
+			}
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return count, isArray, err
+			}
+			count++
+		}
+	default:
+		return 0, false, fmt.Errorf("not an array or object")
+	}
+
+	return count, isArray, nil
+}
+
+// handleBrowse serves GET /api/browse?path=sub/dir&depth=N&sort=name|size|mtime
+func handleBrowse(root string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if root == "" {
+			respondJSON(w, BrowseResponse{Error: "Not in directory mode"})
+			return
+		}
+
+		q := r.URL.Query()
+		depth := maxBrowseDepth
+		if d, err := strconv.Atoi(q.Get("depth")); err == nil && d >= 0 && d < maxBrowseDepth {
+			depth = d
+		}
+
+		dir, err := resolveBrowsePath(root, q.Get("path"))
+		if err != nil {
+			respondJSON(w, BrowseResponse{Error: err.Error()})
+			return
+		}
+
+		items, err := browseDir(root, dir, depth, q.Get("sort"))
+		if err != nil {
+			respondJSON(w, BrowseResponse{Error: err.Error()})
+			return
+		}
+
+		rel, _ := filepath.Rel(root, dir)
+		if rel == "." {
+			rel = ""
+		}
+		respondJSON(w, BrowseResponse{Items: items, Path: filepath.ToSlash(rel)})
+	}
+}
+
+// readJSONFile reads and validates the JSON file at the given root-relative
+// path, rejecting traversal outside root.
+func readJSONFile(root, relPath string) ([]byte, error) {
+	full, err := resolveBrowsePath(root, relPath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return nil, err
+	}
+
+	var js json.RawMessage
+	if err := json.Unmarshal(data, &js); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return data, nil
+}
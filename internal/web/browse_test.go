@@ -0,0 +1,87 @@
+package web
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestResolveBrowsePath pins the path-traversal guard backing both
+// /api/browse and /api/load: nothing derived from a user-supplied relPath
+// should ever resolve outside root.
+func TestResolveBrowsePath(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		relPath string
+		wantErr bool
+	}{
+		{"empty path resolves to root", "", false},
+		{"plain subpath", "sub/file.json", false},
+		{"dot-prefixed subpath", "./sub/file.json", false},
+		{"leading slash is treated as relative to root", "/sub/file.json", false},
+		{"simple traversal", "../escape.json", true},
+		{"nested traversal", "sub/../../escape.json", true},
+		{"traversal past root via many levels", "../../../../etc/passwd", true},
+		{"traversal that cancels out stays inside root", "sub/../sub/file.json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveBrowsePath(root, tt.relPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolveBrowsePath(%q) = %q, want error", tt.relPath, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveBrowsePath(%q): unexpected error: %v", tt.relPath, err)
+			}
+
+			rootAbs, _ := filepath.Abs(root)
+			if got != rootAbs && !strings.HasPrefix(got, rootAbs+string(filepath.Separator)) {
+				t.Errorf("resolveBrowsePath(%q) = %q, escapes root %q", tt.relPath, got, rootAbs)
+			}
+		})
+	}
+}
+
+// TestReadJSONFile exercises the same guard through readJSONFile, which is
+// what /api/load actually calls.
+func TestReadJSONFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "valid.json"), []byte(`{"a":1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "invalid.json"), []byte(`not json`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outsideDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outsideDir, "secret.json"), []byte(`{"leak":true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := readJSONFile(root, "valid.json"); err != nil {
+		t.Errorf("readJSONFile(valid.json): unexpected error: %v", err)
+	}
+
+	if _, err := readJSONFile(root, "invalid.json"); err == nil {
+		t.Errorf("readJSONFile(invalid.json): expected an error for malformed JSON")
+	}
+
+	traversal := "../" + filepath.Base(outsideDir) + "/secret.json"
+	if _, err := readJSONFile(root, traversal); err == nil {
+		t.Errorf("readJSONFile(%q): expected traversal outside root to be rejected", traversal)
+	}
+
+	if _, err := readJSONFile(root, "missing.json"); err == nil {
+		t.Errorf("readJSONFile(missing.json): expected an error for a nonexistent file")
+	}
+}
@@ -1,7 +1,6 @@
 package web
 
 import (
-	"bytes"
 	"embed"
 	"encoding/json"
 	"fmt"
@@ -9,18 +8,90 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jq-view/jq-view/internal/jq"
-	"github.com/olekukonko/tablewriter"
 )
 
+// sessionCookie is the name of the cookie used to key per-client state
+// (currently loaded file) so that concurrent browser tabs don't stomp on
+// one another.
+const sessionCookie = "jqview_session"
+
+// sessionStore tracks per-session state, guarded by mu.
+type sessionStore struct {
+	mu      sync.Mutex
+	current map[string]string // session id -> currently loaded relative path
+}
+
+func newSessionStore() *sessionStore {
+	return &sessionStore{current: make(map[string]string)}
+}
+
+func (s *sessionStore) get(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current[id]
+}
+
+func (s *sessionStore) set(id, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current[id] = path
+}
+
+// sessionID returns the caller's session id, issuing a fresh cookie if none
+// is present yet.
+func sessionID(w http.ResponseWriter, r *http.Request) string {
+	if c, err := r.Cookie(sessionCookie); err == nil && c.Value != "" {
+		return c.Value
+	}
+	id := uuid.NewString()
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: id, Path: "/"})
+	return id
+}
+
+// loadedStore tracks, per session, the document most recently loaded via
+// /api/load, so that features with no per-request payload of their own
+// (permalinks, schema inference, autocomplete) have something to run
+// against. A session with no /api/load of its own falls back to initial,
+// the document the server started with — this keeps single-file and
+// stdin mode working with no sessions involved at all, while directory
+// mode no longer lets one client's /api/load stomp on another's view.
+type loadedStore struct {
+	mu         sync.Mutex
+	initial    []byte
+	perSession map[string][]byte
+}
+
+func newLoadedStore(initial []byte) *loadedStore {
+	return &loadedStore{initial: initial, perSession: make(map[string][]byte)}
+}
+
+func (l *loadedStore) get(id string) []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if data, ok := l.perSession[id]; ok {
+		return data
+	}
+	return l.initial
+}
+
+func (l *loadedStore) set(id string, data []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.perSession[id] = data
+}
+
 //go:embed index.html
 var indexHTML embed.FS
 
 type QueryRequest struct {
 	Data       json.RawMessage `json:"data"`
 	Expression string          `json:"expression"`
-	Format     string          `json:"format"` // json or table
+	Format     string          `json:"format"` // json, table, csv, tsv, markdown, or html
 }
 
 type QueryResponse struct {
@@ -35,7 +106,7 @@ type FileListResponse struct {
 }
 
 type LoadFileRequest struct {
-	Filename string `json:"filename"`
+	Filename string `json:"filename"` // path relative to the directory root
 }
 
 type LoadFileResponse struct {
@@ -43,17 +114,26 @@ type LoadFileResponse struct {
 	Error string          `json:"error,omitempty"`
 }
 
-// Handler returns the HTTP handler for the web UI
-func Handler(initialData []byte, dirPath string) http.Handler {
+// Handler returns the HTTP handler for the web UI. initialPath is the
+// absolute path initialData was read from in single-file mode (empty when
+// it came from stdin, and in directory mode, where the session's loaded
+// file is used instead); it is re-opened on demand by /api/query/stream so
+// large files don't have
+// to stay fully buffered in memory just to be streamed. store, if non-nil,
+// backs the saved-snippet library, query history, and permalinks.
+func Handler(initialData []byte, dirPath string, initialPath string, store *Store) http.Handler {
 	mux := http.NewServeMux()
 
-	currentFile := ""
+	sessions := newSessionStore()
+	loaded := newLoadedStore(initialData)
+
+	firstFile := ""
 	if dirPath != "" {
 		// Find the first JSON file name
 		entries, _ := os.ReadDir(dirPath)
 		for _, e := range entries {
 			if !e.IsDir() && filepath.Ext(e.Name()) == ".json" {
-				currentFile = e.Name()
+				firstFile = e.Name()
 				break
 			}
 		}
@@ -67,6 +147,11 @@ func Handler(initialData []byte, dirPath string) http.Handler {
 			return
 		}
 
+		id := sessionID(w, r)
+		if dirPath != "" && sessions.get(id) == "" {
+			sessions.set(id, firstFile)
+		}
+
 		// Replace placeholder with actual data
 		output := strings.Replace(string(html), "{{INITIAL_DATA}}", string(initialData), 1)
 		// Replace directory mode flag
@@ -75,13 +160,14 @@ func Handler(initialData []byte, dirPath string) http.Handler {
 			dirModeStr = "true"
 		}
 		output = strings.Replace(output, "{{DIR_MODE}}", dirModeStr, 1)
-		output = strings.Replace(output, "{{CURRENT_FILE}}", currentFile, 1)
+		output = strings.Replace(output, "{{CURRENT_FILE}}", sessions.get(id), 1)
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.Write([]byte(output))
 	})
 
-	// API: list files in directory
+	// API: list files in directory (flat, top level only; see /api/browse
+	// for recursive listings with metadata)
 	mux.HandleFunc("/api/files", func(w http.ResponseWriter, r *http.Request) {
 		if dirPath == "" {
 			respondJSON(w, FileListResponse{Files: nil})
@@ -101,14 +187,18 @@ func Handler(initialData []byte, dirPath string) http.Handler {
 			}
 		}
 
+		id := sessionID(w, r)
 		respondJSON(w, FileListResponse{
 			Files:       files,
-			CurrentFile: currentFile,
+			CurrentFile: sessions.get(id),
 			DirPath:     dirPath,
 		})
 	})
 
-	// API: load a specific file
+	// API: recursive directory browsing with sorting and file metadata
+	mux.HandleFunc("/api/browse", handleBrowse(dirPath))
+
+	// API: load a file at a path relative to the directory root
 	mux.HandleFunc("/api/load", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", 405)
@@ -126,30 +216,27 @@ func Handler(initialData []byte, dirPath string) http.Handler {
 			return
 		}
 
-		// Security: ensure filename doesn't contain path traversal
-		if strings.Contains(req.Filename, "..") || strings.Contains(req.Filename, "/") {
-			respondJSON(w, LoadFileResponse{Error: "Invalid filename"})
-			return
-		}
-
-		filePath := filepath.Join(dirPath, req.Filename)
-		data, err := os.ReadFile(filePath)
+		data, err := readJSONFile(dirPath, req.Filename)
 		if err != nil {
 			respondJSON(w, LoadFileResponse{Error: err.Error()})
 			return
 		}
 
-		// Validate JSON
-		var js json.RawMessage
-		if err := json.Unmarshal(data, &js); err != nil {
-			respondJSON(w, LoadFileResponse{Error: "Invalid JSON: " + err.Error()})
-			return
-		}
-
-		currentFile = req.Filename
-		respondJSON(w, LoadFileResponse{Data: js})
+		id := sessionID(w, r)
+		sessions.set(id, req.Filename)
+		loaded.set(id, data)
+		respondJSON(w, LoadFileResponse{Data: data})
 	})
 
+	loadedForRequest := func(w http.ResponseWriter, r *http.Request) []byte {
+		return loaded.get(sessionID(w, r))
+	}
+
+	if store != nil {
+		registerSnippetRoutes(mux, store, loadedForRequest)
+	}
+	registerSchemaRoutes(mux, loadedForRequest)
+
 	// API: execute jq query
 	mux.HandleFunc("/api/query", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -169,195 +256,142 @@ func Handler(initialData []byte, dirPath string) http.Handler {
 			return
 		}
 
-		// Convert to table if requested
-		if req.Format == "table" {
-			tableStr, err := jsonToTable(result)
+		recordHistory := func(resultBytes int) {
+			if store == nil {
+				return
+			}
+			store.RecordHistory(HistoryEntry{
+				Expression:  req.Expression,
+				Format:      req.Format,
+				Timestamp:   time.Now(),
+				ResultBytes: resultBytes,
+			})
+		}
+
+		// Render in the requested format (json passes the result through
+		// unchanged; everything else goes through a Renderer)
+		if req.Format != "" && req.Format != "json" {
+			renderer, err := rendererFor(req.Format)
 			if err != nil {
+				recordHistory(len(result))
 				respondJSON(w, QueryResponse{Result: string(result)})
 				return
 			}
-			respondJSON(w, QueryResponse{Result: tableStr})
+			rendered, err := renderer.Render(result)
+			if err != nil {
+				recordHistory(len(result))
+				respondJSON(w, QueryResponse{Result: string(result)})
+				return
+			}
+			recordHistory(len(rendered))
+			respondJSON(w, QueryResponse{Result: rendered})
 			return
 		}
 
+		recordHistory(len(result))
 		respondJSON(w, QueryResponse{Result: string(result)})
 	})
 
-	return mux
-}
+	// API: run a query and return the rendered result as a downloadable file
+	mux.HandleFunc("/api/export", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
 
-func respondJSON(w http.ResponseWriter, data any) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(data)
-}
+		format := r.URL.Query().Get("format")
+		renderer, err := rendererFor(format)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
 
-func jsonToTable(data []byte) (string, error) {
-	var parsed any
-	if err := json.Unmarshal(data, &parsed); err != nil {
-		return "", fmt.Errorf("cannot parse JSON")
-	}
+		var req QueryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
 
-	var buf bytes.Buffer
-	renderTables(&buf, "", parsed)
+		result, err := jq.Execute(req.Expression, req.Data)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
 
-	if buf.Len() == 0 {
-		return "", fmt.Errorf("no tabular data found")
-	}
+		rendered, err := renderer.Render(result)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
 
-	return buf.String(), nil
-}
+		w.Header().Set("Content-Type", renderer.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=export.%s", exportExtension(format)))
+		w.Write([]byte(rendered))
+	})
 
-// renderTables recursively renders tables for each level of the data
-func renderTables(buf *bytes.Buffer, title string, data any) {
-	switch v := data.(type) {
-	case []any:
-		// Array of objects -> render as table
-		if len(v) == 0 {
+	// API: run a query over NDJSON input, streaming results as they arrive
+	mux.HandleFunc("/api/query/stream", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", 405)
 			return
 		}
-		// Check if first element is an object
-		if obj, ok := v[0].(map[string]any); ok {
-			renderArrayTable(buf, title, v)
-			// Recursively render nested arrays/objects
-			for key := range obj {
-				var nestedArrays []any
-				for _, item := range v {
-					if m, ok := item.(map[string]any); ok {
-						if nested, exists := m[key]; exists {
-							if arr, isArr := nested.([]any); isArr {
-								nestedArrays = append(nestedArrays, arr...)
-							}
-						}
-					}
-				}
-				if len(nestedArrays) > 0 {
-					renderTables(buf, key, nestedArrays)
-				}
-			}
-		} else {
-			// Array of primitives
-			renderPrimitiveArray(buf, title, v)
+
+		var req QueryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
 		}
-	case map[string]any:
-		// Single object - collect leaf values and nested structures
-		leafs := make(map[string]any)
-		for key, val := range v {
-			switch nested := val.(type) {
-			case []any:
-				renderTables(buf, key, nested)
-			case map[string]any:
-				renderTables(buf, key, nested)
-			default:
-				leafs[key] = val
-			}
+
+		var source string
+		if dirPath != "" {
+			source = filepath.Join(dirPath, sessions.get(sessionID(w, r)))
+		} else {
+			source = initialPath
 		}
-		// Render leaf values as single-row table
-		if len(leafs) > 0 {
-			renderObjectTable(buf, title, leafs)
+		if source == "" {
+			http.Error(w, "no file to stream (input came from stdin)", 400)
+			return
 		}
-	}
-}
-
-// renderArrayTable renders an array of objects as a table
-func renderArrayTable(buf *bytes.Buffer, title string, items []any) {
-	if len(items) == 0 {
-		return
-	}
-
-	// Collect all leaf keys (non-object, non-array)
-	firstObj, ok := items[0].(map[string]any)
-	if !ok {
-		return
-	}
 
-	var headers []string
-	for k, v := range firstObj {
-		switch v.(type) {
-		case []any, map[string]any:
-			// Skip nested structures
-		default:
-			headers = append(headers, k)
+		f, err := os.Open(source)
+		if err != nil {
+			http.Error(w, err.Error(), 404)
+			return
 		}
-	}
-
-	if len(headers) == 0 {
-		return
-	}
-
-	// Sort headers for consistent order
-	// (keeping insertion order from map iteration)
-
-	if title != "" {
-		buf.WriteString(fmt.Sprintf("\n── %s ──\n", title))
-	}
+		defer f.Close()
 
-	table := tablewriter.NewTable(buf)
-	table.Header(toAny(headers)...)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Transfer-Encoding", "chunked")
 
-	for _, item := range items {
-		if obj, ok := item.(map[string]any); ok {
-			var row []any
-			for _, h := range headers {
-				row = append(row, formatValue(obj[h]))
-			}
-			table.Append(row...)
+		flusher, _ := w.(http.Flusher)
+		fw := flushWriter{w: w, flusher: flusher}
+		if err := jq.ExecuteStream(req.Expression, f, fw, jq.StreamOpts{Compact: true}); err != nil {
+			fmt.Fprintf(fw, `{"error": %q}`+"\n", err.Error())
 		}
-	}
+	})
 
-	table.Render()
+	return mux
 }
 
-// renderObjectTable renders a single object as a table
-func renderObjectTable(buf *bytes.Buffer, title string, obj map[string]any) {
-	if len(obj) == 0 {
-		return
-	}
-
-	var headers []string
-	var values []any
-	for k, v := range obj {
-		headers = append(headers, k)
-		values = append(values, formatValue(v))
-	}
-
-	if title != "" {
-		buf.WriteString(fmt.Sprintf("\n── %s ──\n", title))
-	}
-
-	table := tablewriter.NewTable(buf)
-	table.Header(toAny(headers)...)
-	table.Append(values...)
-	table.Render()
+// flushWriter flushes the underlying http.ResponseWriter after every write
+// so streamed results reach the browser as they are produced, not only once
+// the handler returns.
+type flushWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
 }
 
-// renderPrimitiveArray renders an array of primitive values
-func renderPrimitiveArray(buf *bytes.Buffer, title string, items []any) {
-	if len(items) == 0 {
-		return
-	}
-
-	if title == "" {
-		title = "values"
-	}
-
-	buf.WriteString(fmt.Sprintf("\n── %s ──\n", title))
-
-	table := tablewriter.NewTable(buf)
-	table.Header(title)
-
-	for _, item := range items {
-		table.Append(formatValue(item))
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if fw.flusher != nil {
+		fw.flusher.Flush()
 	}
-
-	table.Render()
+	return n, err
 }
 
-func toAny(s []string) []any {
-	r := make([]any, len(s))
-	for i, v := range s {
-		r[i] = v
-	}
-	return r
+func respondJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
 }
 
 func formatValue(v any) string {
@@ -0,0 +1,372 @@
+package web
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Renderer turns the result of a jq query into a display- or export-ready
+// string for one output format.
+type Renderer interface {
+	// Render converts parsed JSON result data into the renderer's format.
+	Render(data []byte) (string, error)
+	// ContentType is the MIME type to send with exported output.
+	ContentType() string
+}
+
+// rendererFor looks up the Renderer for a format name as used in
+// QueryRequest.Format and the /api/export "format" query parameter.
+func rendererFor(format string) (Renderer, error) {
+	switch format {
+	case "table":
+		return asciiRenderer{}, nil
+	case "csv":
+		return delimitedRenderer{comma: ','}, nil
+	case "tsv":
+		return delimitedRenderer{comma: '\t'}, nil
+	case "markdown":
+		return markdownRenderer{}, nil
+	case "html":
+		return htmlRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// exportExtension maps a format name to the file extension used for
+// Content-Disposition on /api/export.
+func exportExtension(format string) string {
+	switch format {
+	case "markdown":
+		return "md"
+	case "table":
+		return "txt"
+	default:
+		return format
+	}
+}
+
+// tableSection is one titled table extracted from a JSON document: a flat
+// grid of headers and string cells, with nested arrays/objects broken out
+// into their own sibling sections rather than nested within a cell.
+type tableSection struct {
+	title   string
+	headers []string
+	rows    [][]string
+}
+
+// collectSections walks parsed JSON the same way the original ASCII table
+// view did: an array of objects becomes a table with one row per element,
+// a bare object becomes a single-row table of its leaf fields, and any
+// array- or object-valued field is recursively broken out into its own
+// section named after the field. For an array of objects only the
+// array-valued fields are broken out; object-valued fields are skipped.
+func collectSections(title string, data any) []tableSection {
+	switch v := data.(type) {
+	case []any:
+		if len(v) == 0 {
+			return nil
+		}
+		if _, ok := v[0].(map[string]any); ok {
+			return collectArraySections(title, v)
+		}
+		return []tableSection{primitiveArraySection(title, v)}
+
+	case map[string]any:
+		var sections []tableSection
+		leafs := make(map[string]any)
+		var leafOrder []string
+		for key, val := range v {
+			switch nested := val.(type) {
+			case []any, map[string]any:
+				sections = append(sections, collectSections(key, nested)...)
+			default:
+				if _, seen := leafs[key]; !seen {
+					leafOrder = append(leafOrder, key)
+				}
+				leafs[key] = val
+			}
+		}
+		if len(leafs) > 0 {
+			var headers []string
+			var row []string
+			for _, k := range leafOrder {
+				headers = append(headers, k)
+				row = append(row, formatValue(leafs[k]))
+			}
+			sections = append([]tableSection{{title: title, headers: headers, rows: [][]string{row}}}, sections...)
+		}
+		return sections
+
+	default:
+		return nil
+	}
+}
+
+func collectArraySections(title string, items []any) []tableSection {
+	firstObj, ok := items[0].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var headers []string
+	for k, v := range firstObj {
+		switch v.(type) {
+		case []any, map[string]any:
+			// nested arrays get their own section below; nested objects
+			// are left out of the table
+		default:
+			headers = append(headers, k)
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		row := make([]string, len(headers))
+		for i, h := range headers {
+			row[i] = formatValue(obj[h])
+		}
+		rows = append(rows, row)
+	}
+
+	sections := []tableSection{{title: title, headers: headers, rows: rows}}
+
+	for key := range firstObj {
+		var nestedArrays []any
+		for _, item := range items {
+			if m, ok := item.(map[string]any); ok {
+				if nested, exists := m[key]; exists {
+					if arr, isArr := nested.([]any); isArr {
+						nestedArrays = append(nestedArrays, arr...)
+					}
+				}
+			}
+		}
+		if len(nestedArrays) > 0 {
+			sections = append(sections, collectSections(key, nestedArrays)...)
+		}
+	}
+
+	return sections
+}
+
+func primitiveArraySection(title string, items []any) tableSection {
+	if title == "" {
+		title = "values"
+	}
+	rows := make([][]string, len(items))
+	for i, item := range items {
+		rows[i] = []string{formatValue(item)}
+	}
+	return tableSection{title: title, headers: []string{title}, rows: rows}
+}
+
+func parseSections(data []byte) ([]tableSection, error) {
+	var parsed any
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("cannot parse JSON")
+	}
+
+	sections := collectSections("", parsed)
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("no tabular data found")
+	}
+	return sections, nil
+}
+
+// isNumericColumn reports whether every cell in a column parses as a
+// number, used to right-align numeric columns in markdown tables.
+func isNumericColumn(rows [][]string, col int) bool {
+	if len(rows) == 0 {
+		return false
+	}
+	for _, row := range rows {
+		if col >= len(row) {
+			return false
+		}
+		if _, err := strconv.ParseFloat(row[col], 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// --- ASCII table (the original "table" format) -----------------------
+
+type asciiRenderer struct{}
+
+func (asciiRenderer) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (asciiRenderer) Render(data []byte) (string, error) {
+	sections, err := parseSections(data)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	for _, s := range sections {
+		if s.title != "" {
+			buf.WriteString(fmt.Sprintf("\n── %s ──\n", s.title))
+		}
+		table := tablewriter.NewTable(&buf)
+		table.Header(toAny(s.headers)...)
+		for _, row := range s.rows {
+			table.Append(toAny(row)...)
+		}
+		table.Render()
+	}
+	return buf.String(), nil
+}
+
+func toAny(s []string) []any {
+	r := make([]any, len(s))
+	for i, v := range s {
+		r[i] = v
+	}
+	return r
+}
+
+// --- CSV / TSV ----------------------------------------------------------
+
+type delimitedRenderer struct {
+	comma rune
+}
+
+func (delimitedRenderer) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (d delimitedRenderer) Render(data []byte) (string, error) {
+	sections, err := parseSections(data)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	for i, s := range sections {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if s.title != "" {
+			buf.WriteString(fmt.Sprintf("# %s\n", s.title))
+		}
+
+		w := csv.NewWriter(&buf)
+		w.Comma = d.comma
+		w.Write(s.headers)
+		for _, row := range s.rows {
+			w.Write(row)
+		}
+		w.Flush()
+	}
+	return buf.String(), nil
+}
+
+// --- Markdown -------------------------------------------------------------
+
+type markdownRenderer struct{}
+
+func (markdownRenderer) ContentType() string { return "text/markdown; charset=utf-8" }
+
+func (markdownRenderer) Render(data []byte) (string, error) {
+	sections, err := parseSections(data)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	for i, s := range sections {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if s.title != "" {
+			buf.WriteString(fmt.Sprintf("## %s\n\n", s.title))
+		}
+
+		buf.WriteString("| " + strings.Join(escapeMarkdownRow(s.headers), " | ") + " |\n")
+
+		aligns := make([]string, len(s.headers))
+		for col := range s.headers {
+			if isNumericColumn(s.rows, col) {
+				aligns[col] = "---:"
+			} else {
+				aligns[col] = "---"
+			}
+		}
+		buf.WriteString("| " + strings.Join(aligns, " | ") + " |\n")
+
+		for _, row := range s.rows {
+			buf.WriteString("| " + strings.Join(escapeMarkdownRow(row), " | ") + " |\n")
+		}
+	}
+	return buf.String(), nil
+}
+
+// escapeMarkdownRow escapes every cell in row for safe inclusion in a GFM
+// pipe table; see escapeMarkdownCell.
+func escapeMarkdownRow(row []string) []string {
+	out := make([]string, len(row))
+	for i, cell := range row {
+		out[i] = escapeMarkdownCell(cell)
+	}
+	return out
+}
+
+// escapeMarkdownCell makes a cell value safe to place inside a GFM pipe
+// table cell: "|" would otherwise be parsed as a column boundary, and a
+// raw newline would break the row onto multiple lines (GFM table rows
+// must be a single line).
+func escapeMarkdownCell(s string) string {
+	r := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+	return r.Replace(s)
+}
+
+// --- HTML -------------------------------------------------------------
+
+type htmlRenderer struct{}
+
+func (htmlRenderer) ContentType() string { return "text/html; charset=utf-8" }
+
+func (htmlRenderer) Render(data []byte) (string, error) {
+	sections, err := parseSections(data)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	for _, s := range sections {
+		if s.title != "" {
+			fmt.Fprintf(&buf, "<h3>%s</h3>\n", htmlEscape(s.title))
+		}
+		buf.WriteString("<table>\n  <thead>\n    <tr>")
+		for _, h := range s.headers {
+			fmt.Fprintf(&buf, "<th>%s</th>", htmlEscape(h))
+		}
+		buf.WriteString("</tr>\n  </thead>\n  <tbody>\n")
+		for _, row := range s.rows {
+			buf.WriteString("    <tr>")
+			for _, cell := range row {
+				fmt.Fprintf(&buf, "<td>%s</td>", htmlEscape(cell))
+			}
+			buf.WriteString("</tr>\n")
+		}
+		buf.WriteString("  </tbody>\n</table>\n")
+	}
+	return buf.String(), nil
+}
+
+func htmlEscape(s string) string {
+	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+	return r.Replace(s)
+}
@@ -0,0 +1,166 @@
+package web
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRendererFor(t *testing.T) {
+	tests := []struct {
+		format    string
+		wantType  string
+		wantError bool
+	}{
+		{"table", "text/plain; charset=utf-8", false},
+		{"csv", "text/csv; charset=utf-8", false},
+		{"tsv", "text/csv; charset=utf-8", false},
+		{"markdown", "text/markdown; charset=utf-8", false},
+		{"html", "text/html; charset=utf-8", false},
+		{"bogus", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			r, err := rendererFor(tt.format)
+			if tt.wantError {
+				if err == nil {
+					t.Fatalf("rendererFor(%q): expected error", tt.format)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("rendererFor(%q): unexpected error: %v", tt.format, err)
+			}
+			if got := r.ContentType(); got != tt.wantType {
+				t.Errorf("ContentType() = %q, want %q", got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestDelimitedRendererCSV(t *testing.T) {
+	data := []byte(`[{"name":"Alice"},{"name":"Bob"}]`)
+
+	out, err := (delimitedRenderer{comma: ','}).Render(data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	want := "name\nAlice\nBob\n"
+	if out != want {
+		t.Errorf("Render() = %q, want %q", out, want)
+	}
+}
+
+func TestDelimitedRendererTSV(t *testing.T) {
+	data := []byte(`[{"name":"Alice"}]`)
+
+	out, err := (delimitedRenderer{comma: '\t'}).Render(data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	want := "name\nAlice\n"
+	if out != want {
+		t.Errorf("Render() = %q, want %q", out, want)
+	}
+}
+
+func TestDelimitedRendererQuotesSpecialChars(t *testing.T) {
+	data := []byte(`[{"note":"a,b"}]`)
+
+	out, err := (delimitedRenderer{comma: ','}).Render(data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.Contains(out, `"a,b"`) {
+		t.Errorf("Render() = %q, want a quoted cell containing a comma", out)
+	}
+}
+
+func TestMarkdownRendererEscapesPipesAndNewlines(t *testing.T) {
+	data := []byte(`[{"note":"a|b\nc"}]`)
+
+	out, err := (markdownRenderer{}).Render(data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	if strings.Contains(out, "a|b") {
+		t.Errorf("Render() left an unescaped pipe in a cell: %q", out)
+	}
+	if !strings.Contains(out, `a\|b<br>c`) {
+		t.Errorf("Render() = %q, want the cell escaped to `a\\|b<br>c`", out)
+	}
+
+	// The table structure itself should still be a valid single-column GFM
+	// table: header, alignment row, one data row.
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Render() produced %d lines, want 3 (header, align, data): %q", len(lines), out)
+	}
+}
+
+func TestEscapeMarkdownCell(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"a|b", `a\|b`},
+		{"a\nb", "a<br>b"},
+		{"a\r\nb", "a<br>b"},
+		{"a|b\nc|d", `a\|b<br>c\|d`},
+	}
+	for _, tt := range tests {
+		if got := escapeMarkdownCell(tt.in); got != tt.want {
+			t.Errorf("escapeMarkdownCell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLRendererEscapesEntities(t *testing.T) {
+	data := []byte(`[{"note":"<b>&\"x\"</b>"}]`)
+
+	out, err := (htmlRenderer{}).Render(data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	if strings.Contains(out, "<b>&\"x\"</b>") {
+		t.Errorf("Render() left raw HTML unescaped: %q", out)
+	}
+	if !strings.Contains(out, "&lt;b&gt;&amp;&quot;x&quot;&lt;/b&gt;") {
+		t.Errorf("Render() = %q, want the cell HTML-escaped", out)
+	}
+	if !strings.Contains(out, "<table>") || !strings.Contains(out, "<th>note</th>") {
+		t.Errorf("Render() = %q, want a <table> with a note header", out)
+	}
+}
+
+func TestAsciiRendererRendersValues(t *testing.T) {
+	data := []byte(`[{"name":"Alice"},{"name":"Bob"}]`)
+
+	out, err := (asciiRenderer{}).Render(data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.Contains(out, "Alice") || !strings.Contains(out, "Bob") {
+		t.Errorf("Render() = %q, want it to contain both row values", out)
+	}
+}
+
+func TestExportExtension(t *testing.T) {
+	tests := map[string]string{
+		"markdown": "md",
+		"table":    "txt",
+		"csv":      "csv",
+		"tsv":      "tsv",
+		"html":     "html",
+	}
+	for format, want := range tests {
+		if got := exportExtension(format); got != want {
+			t.Errorf("exportExtension(%q) = %q, want %q", format, got, want)
+		}
+	}
+}
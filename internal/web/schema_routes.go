@@ -0,0 +1,67 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jq-view/jq-view/internal/schema"
+)
+
+// ValidateRequest is the payload for POST /api/validate.
+type ValidateRequest struct {
+	Data   json.RawMessage `json:"data"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// ValidateResponse reports the validation errors found, if any.
+type ValidateResponse struct {
+	Valid  bool                     `json:"valid"`
+	Errors []schema.ValidationError `json:"errors,omitempty"`
+	Error  string                   `json:"error,omitempty"`
+}
+
+// registerSchemaRoutes wires the JSON Schema validation, inference, and
+// autocomplete endpoints. loadedData supplies the document that
+// /api/schema/infer and /api/complete operate on, scoped to the
+// requesting client's session.
+func registerSchemaRoutes(mux *http.ServeMux, loadedData func(w http.ResponseWriter, r *http.Request) []byte) {
+	mux.HandleFunc("/api/validate", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
+
+		var req ValidateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondJSON(w, ValidateResponse{Error: err.Error()})
+			return
+		}
+
+		errs, err := schema.Validate(req.Data, req.Schema)
+		if err != nil {
+			respondJSON(w, ValidateResponse{Error: err.Error()})
+			return
+		}
+
+		respondJSON(w, ValidateResponse{Valid: len(errs) == 0, Errors: errs})
+	})
+
+	mux.HandleFunc("/api/schema/infer", func(w http.ResponseWriter, r *http.Request) {
+		sch, err := schema.Infer(loadedData(w, r))
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		respondJSON(w, sch)
+	})
+
+	mux.HandleFunc("/api/complete", func(w http.ResponseWriter, r *http.Request) {
+		sch, err := schema.Infer(loadedData(w, r))
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		prefix := r.URL.Query().Get("prefix")
+		respondJSON(w, map[string][]string{"completions": sch.CompletionsFor(prefix)})
+	})
+}
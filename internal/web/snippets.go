@@ -0,0 +1,307 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jq-view/jq-view/internal/jq"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	snippetsBucket = []byte("snippets")
+	historyBucket  = []byte("history")
+)
+
+// maxHistoryEntries bounds how many query-history entries are kept; older
+// entries are dropped once the limit is exceeded.
+const maxHistoryEntries = 500
+
+// Snippet is a named, reusable jq expression.
+type Snippet struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	Format     string    `json:"format"`
+	Tags       []string  `json:"tags,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// HistoryEntry records one executed query, auto-written by /api/query.
+type HistoryEntry struct {
+	Expression  string    `json:"expression"`
+	Format      string    `json:"format"`
+	Timestamp   time.Time `json:"timestamp"`
+	ResultBytes int       `json:"resultBytes"`
+}
+
+// Store persists snippets and query history to a BoltDB file.
+type Store struct {
+	db *bolt.DB
+}
+
+// DefaultStatePath returns the default location for the state database,
+// honoring $XDG_CONFIG_HOME when set.
+func DefaultStatePath() string {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "jq-view", "state.db")
+}
+
+// OpenStore opens (creating if necessary) the BoltDB file at path.
+func OpenStore(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(snippetsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(historyBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close releases the underlying database file.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// SaveSnippet assigns an ID and timestamp if not already set, then persists it.
+func (s *Store) SaveSnippet(snip Snippet) (Snippet, error) {
+	if snip.ID == "" {
+		snip.ID = uuid.NewString()
+	}
+	if snip.CreatedAt.IsZero() {
+		snip.CreatedAt = time.Now()
+	}
+
+	return snip, s.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(snip)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(snippetsBucket).Put([]byte(snip.ID), data)
+	})
+}
+
+// ListSnippets returns all saved snippets, newest first.
+func (s *Store) ListSnippets() ([]Snippet, error) {
+	var snippets []Snippet
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(snippetsBucket).ForEach(func(_, v []byte) error {
+			var snip Snippet
+			if err := json.Unmarshal(v, &snip); err != nil {
+				return err
+			}
+			snippets = append(snippets, snip)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(snippets, func(i, j int) bool {
+		return snippets[i].CreatedAt.After(snippets[j].CreatedAt)
+	})
+	return snippets, nil
+}
+
+// GetSnippet looks up a snippet by ID.
+func (s *Store) GetSnippet(id string) (Snippet, bool, error) {
+	var snip Snippet
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(snippetsBucket).Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &snip)
+	})
+	return snip, found, err
+}
+
+// DeleteSnippet removes a snippet by ID.
+func (s *Store) DeleteSnippet(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(snippetsBucket).Delete([]byte(id))
+	})
+}
+
+// RecordHistory appends a query-history entry, trimming the oldest entries
+// once maxHistoryEntries is exceeded.
+func (s *Store) RecordHistory(entry HistoryEntry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(historyBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(itob(seq), data); err != nil {
+			return err
+		}
+
+		// Count with a cursor: Stats() reads committed pages only and
+		// would miss the entry just put.
+		c := b.Cursor()
+		n := 0
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			n++
+		}
+		if n > maxHistoryEntries {
+			k, _ := c.First()
+			return b.Delete(k)
+		}
+		return nil
+	})
+}
+
+// ListHistory returns the most recent history entries, newest first,
+// limited to limit entries (0 means unlimited).
+func (s *Store) ListHistory(limit int) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(historyBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var e HistoryEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			entries = append(entries, e)
+			if limit > 0 && len(entries) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func itob(v uint64) []byte {
+	return []byte(fmt.Sprintf("%020d", v))
+}
+
+// registerSnippetRoutes wires the snippet/history/permalink endpoints onto
+// mux. loadedData supplies the JSON a permalink query runs against, scoped
+// to the requesting client's session.
+func registerSnippetRoutes(mux *http.ServeMux, store *Store, loadedData func(w http.ResponseWriter, r *http.Request) []byte) {
+	mux.HandleFunc("/api/snippets", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			snippets, err := store.ListSnippets()
+			if err != nil {
+				respondJSON(w, map[string]string{"error": err.Error()})
+				return
+			}
+			respondJSON(w, snippets)
+
+		case http.MethodPost:
+			var snip Snippet
+			if err := json.NewDecoder(r.Body).Decode(&snip); err != nil {
+				respondJSON(w, map[string]string{"error": err.Error()})
+				return
+			}
+			saved, err := store.SaveSnippet(snip)
+			if err != nil {
+				respondJSON(w, map[string]string{"error": err.Error()})
+				return
+			}
+			respondJSON(w, saved)
+
+		default:
+			http.Error(w, "Method not allowed", 405)
+		}
+	})
+
+	mux.HandleFunc("/api/snippets/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", 405)
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/api/snippets/")
+		if id == "" {
+			http.Error(w, "Missing snippet id", 400)
+			return
+		}
+		if err := store.DeleteSnippet(id); err != nil {
+			respondJSON(w, map[string]string{"error": err.Error()})
+			return
+		}
+		respondJSON(w, map[string]bool{"deleted": true})
+	})
+
+	mux.HandleFunc("/api/history", func(w http.ResponseWriter, r *http.Request) {
+		limit := 50
+		if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+			limit = n
+		}
+		entries, err := store.ListHistory(limit)
+		if err != nil {
+			respondJSON(w, map[string]string{"error": err.Error()})
+			return
+		}
+		respondJSON(w, entries)
+	})
+
+	// /s/{shortid} resolves a permalink: it loads the snippet's saved
+	// expression and format, and runs it against the currently loaded file.
+	mux.HandleFunc("/s/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/s/")
+		snip, found, err := store.GetSnippet(id)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		if !found {
+			http.Error(w, "Snippet not found", 404)
+			return
+		}
+
+		result, err := jq.Execute(snip.Expression, loadedData(w, r))
+		if err != nil {
+			respondJSON(w, QueryResponse{Error: err.Error()})
+			return
+		}
+		rendered := string(result)
+		if snip.Format != "" && snip.Format != "json" {
+			if renderer, err := rendererFor(snip.Format); err == nil {
+				if out, err := renderer.Render(result); err == nil {
+					rendered = out
+				}
+			}
+		}
+		respondJSON(w, QueryResponse{Result: rendered})
+	})
+}
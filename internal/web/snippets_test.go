@@ -0,0 +1,123 @@
+package web
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := OpenStore(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestStoreSnippetRoundTrip(t *testing.T) {
+	store := openTestStore(t)
+
+	saved, err := store.SaveSnippet(Snippet{Name: "top-level keys", Expression: "keys", Format: "json"})
+	if err != nil {
+		t.Fatalf("SaveSnippet: %v", err)
+	}
+	if saved.ID == "" {
+		t.Error("SaveSnippet did not assign an ID")
+	}
+	if saved.CreatedAt.IsZero() {
+		t.Error("SaveSnippet did not assign a CreatedAt")
+	}
+
+	got, found, err := store.GetSnippet(saved.ID)
+	if err != nil {
+		t.Fatalf("GetSnippet: %v", err)
+	}
+	if !found {
+		t.Fatal("GetSnippet: saved snippet not found")
+	}
+	if got.Expression != "keys" {
+		t.Errorf("GetSnippet: Expression = %q, want %q", got.Expression, "keys")
+	}
+
+	if err := store.DeleteSnippet(saved.ID); err != nil {
+		t.Fatalf("DeleteSnippet: %v", err)
+	}
+	if _, found, err := store.GetSnippet(saved.ID); err != nil {
+		t.Fatalf("GetSnippet after delete: %v", err)
+	} else if found {
+		t.Error("GetSnippet: snippet still found after DeleteSnippet")
+	}
+}
+
+func TestStoreGetSnippetNotFound(t *testing.T) {
+	store := openTestStore(t)
+
+	_, found, err := store.GetSnippet("does-not-exist")
+	if err != nil {
+		t.Fatalf("GetSnippet: %v", err)
+	}
+	if found {
+		t.Error("GetSnippet: expected found=false for an unknown id")
+	}
+}
+
+func TestStoreListSnippetsNewestFirst(t *testing.T) {
+	store := openTestStore(t)
+
+	older, err := store.SaveSnippet(Snippet{Name: "older", CreatedAt: time.Unix(1000, 0)})
+	if err != nil {
+		t.Fatalf("SaveSnippet: %v", err)
+	}
+	newer, err := store.SaveSnippet(Snippet{Name: "newer", CreatedAt: time.Unix(2000, 0)})
+	if err != nil {
+		t.Fatalf("SaveSnippet: %v", err)
+	}
+
+	list, err := store.ListSnippets()
+	if err != nil {
+		t.Fatalf("ListSnippets: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("ListSnippets: got %d entries, want 2", len(list))
+	}
+	if list[0].ID != newer.ID || list[1].ID != older.ID {
+		t.Errorf("ListSnippets: order = [%s, %s], want [%s, %s]", list[0].Name, list[1].Name, newer.Name, older.Name)
+	}
+}
+
+func TestStoreHistoryRoundTrip(t *testing.T) {
+	store := openTestStore(t)
+
+	entries := []HistoryEntry{
+		{Expression: ".a", Format: "json", Timestamp: time.Unix(1, 0), ResultBytes: 10},
+		{Expression: ".b", Format: "csv", Timestamp: time.Unix(2, 0), ResultBytes: 20},
+		{Expression: ".c", Format: "table", Timestamp: time.Unix(3, 0), ResultBytes: 30},
+	}
+	for _, e := range entries {
+		if err := store.RecordHistory(e); err != nil {
+			t.Fatalf("RecordHistory: %v", err)
+		}
+	}
+
+	all, err := store.ListHistory(0)
+	if err != nil {
+		t.Fatalf("ListHistory: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("ListHistory: got %d entries, want 3", len(all))
+	}
+	// Newest first.
+	if all[0].Expression != ".c" || all[2].Expression != ".a" {
+		t.Errorf("ListHistory: order = %v, want newest (.c) first", all)
+	}
+
+	limited, err := store.ListHistory(2)
+	if err != nil {
+		t.Fatalf("ListHistory(2): %v", err)
+	}
+	if len(limited) != 2 {
+		t.Fatalf("ListHistory(2): got %d entries, want 2", len(limited))
+	}
+}
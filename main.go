@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -17,11 +18,14 @@ import (
 func main() {
 	port := flag.Int("p", 8080, "Port to listen on")
 	noBrowser := flag.Bool("no-browser", false, "Don't open browser automatically")
+	ndjson := flag.Bool("ndjson", false, "Treat input as newline-delimited JSON (auto-detected if not set)")
+	statePath := flag.String("state", web.DefaultStatePath(), "Path to the state database for saved snippets and query history")
 	flag.Parse()
 
 	var data []byte
 	var err error
 	var dirPath string
+	var filePath string
 
 	// Read from file, directory, or stdin
 	args := flag.Args()
@@ -40,13 +44,54 @@ func main() {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
-		} else {
-			// Single file mode
-			data, err = os.ReadFile(args[0])
+			data, *ndjson, err = validateWholeInput(data, *ndjson)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			// Single file mode. NDJSON files are the one case this tool is
+			// meant to handle without fully buffering: sniff a bounded
+			// prefix to detect the format, and if it's NDJSON keep only
+			// the first value decoded from that prefix as a UI preview, leaving
+			// /api/query/stream to re-open filePath and stream the rest.
+			filePath, _ = filepath.Abs(args[0])
+			f, ferr := os.Open(args[0])
+			if ferr != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", ferr)
+				os.Exit(1)
+			}
+			prefix, first, detected, serr := sniffNDJSON(f)
+			if serr != nil {
+				f.Close()
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", serr)
 				os.Exit(1)
 			}
+			if *ndjson || detected {
+				*ndjson = true
+				data = first
+				if data == nil {
+					// Forced -ndjson but not even one full value fit in the
+					// sniff window (e.g. a huge first line); fall back to a
+					// placeholder so the initial page still has something
+					// valid to embed. Queries still run via the stream.
+					data = []byte("null")
+				}
+				f.Close()
+			} else {
+				rest, rerr := io.ReadAll(f)
+				f.Close()
+				if rerr != nil {
+					fmt.Fprintf(os.Stderr, "Error reading file: %v\n", rerr)
+					os.Exit(1)
+				}
+				data = append(prefix, rest...)
+				data, *ndjson, err = validateWholeInput(data, *ndjson)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%v\n", err)
+					os.Exit(1)
+				}
+			}
 		}
 	} else {
 		// Check if stdin has data
@@ -57,12 +102,19 @@ func main() {
 				fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
 				os.Exit(1)
 			}
+			data, *ndjson, err = validateWholeInput(data, *ndjson)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
 		} else {
 			fmt.Fprintln(os.Stderr, "Usage: jq-view [file.json | directory]")
 			fmt.Fprintln(os.Stderr, "       cat file.json | jq-view")
 			fmt.Fprintln(os.Stderr, "\nOptions:")
 			fmt.Fprintln(os.Stderr, "  -p PORT        Port to listen on (default 8080)")
 			fmt.Fprintln(os.Stderr, "  -no-browser    Don't open browser automatically")
+			fmt.Fprintln(os.Stderr, "  -ndjson        Treat input as newline-delimited JSON (auto-detected)")
+			fmt.Fprintln(os.Stderr, "  -state PATH    State database for saved snippets and query history")
 			os.Exit(1)
 		}
 	}
@@ -72,13 +124,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Validate JSON
-	var js json.RawMessage
-	if err := json.Unmarshal(data, &js); err != nil {
-		fmt.Fprintf(os.Stderr, "Invalid JSON: %v\n", err)
-		os.Exit(1)
-	}
-
 	addr := fmt.Sprintf(":%d", *port)
 	url := fmt.Sprintf("http://localhost:%d", *port)
 
@@ -86,6 +131,9 @@ func main() {
 	if dirPath != "" {
 		fmt.Printf("Directory mode: %s\n", dirPath)
 	}
+	if *ndjson {
+		fmt.Println("NDJSON mode: streaming queries available at /api/query/stream")
+	}
 	fmt.Println("Press Ctrl+C to stop")
 
 	// Open browser
@@ -93,13 +141,103 @@ func main() {
 		go openBrowser(url)
 	}
 
-	handler := web.Handler(data, dirPath)
+	store, err := web.OpenStore(*statePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not open state database at %s: %v\n", *statePath, err)
+	} else {
+		defer store.Close()
+	}
+
+	handler := web.Handler(data, dirPath, filePath, store)
 	if err := http.ListenAndServe(addr, handler); err != nil {
 		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// looksLikeNDJSON reports whether data appears to be newline-delimited
+// JSON: at least two JSON values that decode one after another. Line
+// breaks themselves are not checked.
+func looksLikeNDJSON(data []byte) bool {
+	return validateNDJSON(data) == nil
+}
+
+// validateNDJSON decodes data as a sequence of JSON values and returns the
+// first decode error, or an error if fewer than two values decode. Used for input that's already fully
+// buffered in memory (directory mode, stdin, or single-file input that
+// turned out not to be NDJSON); see sniffNDJSON for the bounded-prefix
+// check used on NDJSON files so they don't have to be buffered at all.
+func validateNDJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	count := 0
+	for {
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		count++
+	}
+	if count < 2 {
+		return fmt.Errorf("fewer than two JSON values")
+	}
+	return nil
+}
+
+// validateWholeInput validates data that's already fully buffered in
+// memory, auto-detecting NDJSON when the whole blob doesn't parse as a
+// single JSON document. It returns data unchanged alongside the
+// (possibly updated) ndjson flag.
+func validateWholeInput(data []byte, ndjson bool) ([]byte, bool, error) {
+	var js json.RawMessage
+	if err := json.Unmarshal(data, &js); err != nil {
+		if !ndjson && !looksLikeNDJSON(data) {
+			return nil, false, fmt.Errorf("Invalid JSON: %v", err)
+		}
+		ndjson = true
+	}
+	if ndjson {
+		if err := validateNDJSON(data); err != nil {
+			return nil, false, fmt.Errorf("Invalid NDJSON: %v", err)
+		}
+	}
+	return data, ndjson, nil
+}
+
+// ndjsonSniffBytes bounds how much of an NDJSON file is read up front to
+// detect/validate the format and build a UI preview, so /api/query/stream
+// (which re-opens the file) remains the only thing that reads the rest.
+const ndjsonSniffBytes = 64 * 1024
+
+// sniffNDJSON reads a bounded prefix of r and reports whether at least two
+// JSON values decode from it, which is enough to treat the input as
+// newline-delimited JSON without buffering the whole file. It also returns
+// the raw bytes of the first decoded value (a complete JSON value, so
+// valid standalone JSON) to use as an initial-page preview when NDJSON
+// mode is in play.
+func sniffNDJSON(r io.Reader) (prefix []byte, first []byte, ndjson bool, err error) {
+	prefix, err = io.ReadAll(io.LimitReader(r, ndjsonSniffBytes))
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(prefix))
+	count := 0
+	for {
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			break
+		}
+		count++
+		if count == 1 {
+			first = append([]byte(nil), v...)
+		}
+	}
+	return prefix, first, count >= 2, nil
+}
+
 func loadFirstJSONFromDir(dir string) ([]byte, error) {
 	files, err := listJSONFiles(dir)
 	if err != nil {